@@ -0,0 +1,60 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// cacheDir holds on-disk ETag/body pairs for GitHub responses so a restart
+// doesn't have to refetch everything (and doesn't burn rate-limit budget
+// re-reading tags/go.sum bodies that haven't changed).
+const cacheDir = "./cache"
+
+// cacheEntry is what we persist per request URL: the ETag GitHub handed
+// back, the body it was served with, and the Link header (pagination
+// rels), so a future 304 can be replayed as a full response from disk
+// without touching the network.
+type cacheEntry struct {
+	ETag string
+	Link string
+	Body []byte
+}
+
+func cacheKey(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:])
+}
+
+func cachePath(url string) string {
+	return filepath.Join(cacheDir, cacheKey(url)+".json")
+}
+
+func loadCacheEntry(url string) (cacheEntry, bool) {
+	b, err := ioutil.ReadFile(cachePath(url))
+	if err != nil {
+		return cacheEntry{}, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(b, &entry); err != nil {
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+func storeCacheEntry(url string, entry cacheEntry) {
+	if entry.ETag == "" {
+		return
+	}
+	if err := os.MkdirAll(cacheDir, 0o755); err != nil {
+		return
+	}
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	_ = ioutil.WriteFile(cachePath(url), b, 0o644)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// toolVersion is surfaced in the SARIF driver block so code-scanning can
+// tell which build of the tool produced a given result.
+const toolVersion = "dev"
+
+func newRootCmd() *cobra.Command {
+	root := &cobra.Command{
+		Use:           "plugin-tools",
+		Short:         "Validate KrakenD plugin dependencies against a target KrakenD release",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	root.AddCommand(newServeCmd())
+	root.AddCommand(newValidateCmd())
+
+	return root
+}
+
+func newServeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "serve",
+		Short: "Run the finder and validator as an HTTP server on :8080",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			serve()
+			return nil
+		},
+	}
+}
+
+func newValidateCmd() *cobra.Command {
+	var project, krakendVersion, goVersion, sumPath, format string
+
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Validate a local go.sum against a target KrakenD version",
+		Long: "Validate a local go.sum against a target KrakenD version without starting a " +
+			"server, so it can fail a CI build when a plugin's dependencies have diverged.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runValidate(project, krakendVersion, goVersion, sumPath, format)
+		},
+	}
+
+	cmd.Flags().StringVar(&project, "project", defaultProjectName, "project to validate against, as configured in projects.yaml")
+	cmd.Flags().StringVar(&krakendVersion, "krakend", "", "target KrakenD version, e.g. v1.3.0 (required)")
+	cmd.Flags().StringVar(&goVersion, "go", "", "target Go version, e.g. 1.15.8")
+	cmd.Flags().StringVar(&sumPath, "sum", "go.sum", "path to the go.sum file to validate")
+	cmd.Flags().StringVar(&format, "format", "text", "output format: text, json, sarif or github")
+	cmd.MarkFlagRequired("krakend")
+
+	return cmd
+}
+
+func runValidate(project, krakendVersion, goVersion, sumPath, format string) error {
+	allVersions, err := getVersionDeps()
+	if err != nil {
+		return fmt.Errorf("loading known versions: %w", err)
+	}
+	target, ok := allVersions[project][krakendVersion]
+	if !ok {
+		return fmt.Errorf("unknown %s version: %s", project, krakendVersion)
+	}
+
+	f, err := os.Open(sumPath)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", sumPath, err)
+	}
+	defer f.Close()
+
+	diffs := checkLines(target, goVersion, parseSumFile(f))
+
+	if err := writeReport(os.Stdout, format, sumPath, diffs); err != nil {
+		return err
+	}
+
+	if len(diffs) > 0 {
+		os.Exit(1)
+	}
+	return nil
+}
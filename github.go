@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/hashicorp/go-retryablehttp"
+	"golang.org/x/time/rate"
+)
+
+const (
+	userAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_14_4) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/84.0.4147.125 Safari/537.36"
+
+	unauthRequestsPerHour = 60
+	authRequestsPerHour   = 5000
+)
+
+type httpClient func(*http.Request) (*http.Response, error)
+
+// newHTTPClient builds an httpClient that retries transient failures
+// (5xx, 429, connection resets) with exponential backoff via
+// go-retryablehttp, and throttles itself with an x/time/rate limiter sized
+// from GitHub's advertised quota: 60 req/h unauthenticated, 5000 req/h with
+// a token. The limiter's rate is then tightened or relaxed on every
+// response based on the X-RateLimit-Remaining/X-RateLimit-Reset headers,
+// so it adapts if GitHub's quota differs from what we assumed (e.g. it's
+// already been partly spent by another process using the same token).
+//
+// Responses are cached on disk under cacheDir keyed by ETag so a restart
+// doesn't refetch tags or go.sum bodies that haven't changed upstream.
+func newHTTPClient() httpClient {
+	rc := retryablehttp.NewClient()
+	rc.Logger = nil
+
+	token := githubToken()
+	perHour := unauthRequestsPerHour
+	if token != "" {
+		perHour = authRequestsPerHour
+	}
+	limiter := rate.NewLimiter(rate.Every(time.Hour/time.Duration(perHour)), 5)
+
+	return func(req *http.Request) (*http.Response, error) {
+		if err := limiter.Wait(req.Context()); err != nil {
+			return nil, err
+		}
+
+		req.Header.Set("user-agent", userAgent)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		url := req.URL.String()
+		cached, hasCache := loadCacheEntry(url)
+		if hasCache {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+
+		rreq, err := retryablehttp.FromRequest(req)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := rc.Do(rreq)
+		if err != nil {
+			return nil, err
+		}
+		adjustLimiter(limiter, resp.Header)
+
+		if resp.StatusCode == http.StatusNotModified && hasCache {
+			resp.Body.Close()
+			return cachedResponse(req, cached), nil
+		}
+
+		if etag := resp.Header.Get("ETag"); etag != "" {
+			body, err := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+			storeCacheEntry(url, cacheEntry{ETag: etag, Link: resp.Header.Get("Link"), Body: body})
+			resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+
+		return resp, nil
+	}
+}
+
+// githubToken reads the credential used to authenticate against the
+// GitHub API. GITHUB_TOKEN is a personal access token; GITHUB_APP_TOKEN is
+// an installation token minted from a GitHub App's private key (the
+// minting itself happens outside this tool, e.g. in CI).
+func githubToken() string {
+	if t := os.Getenv("GITHUB_TOKEN"); t != "" {
+		return t
+	}
+	return os.Getenv("GITHUB_APP_TOKEN")
+}
+
+// adjustLimiter re-derives the request rate from the quota GitHub says is
+// left and when it resets, so we spread the remaining budget evenly
+// instead of bursting through it and then stalling.
+func adjustLimiter(limiter *rate.Limiter, h http.Header) {
+	remaining, err := strconv.Atoi(h.Get("X-RateLimit-Remaining"))
+	if err != nil || remaining <= 0 {
+		return
+	}
+	resetUnix, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return
+	}
+	until := time.Until(time.Unix(resetUnix, 0))
+	if until <= 0 {
+		return
+	}
+	limiter.SetLimit(rate.Every(until / time.Duration(remaining)))
+}
+
+func cachedResponse(req *http.Request, entry cacheEntry) *http.Response {
+	header := http.Header{"ETag": []string{entry.ETag}}
+	if entry.Link != "" {
+		header.Set("Link", entry.Link)
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Status:     "200 OK (from cache)",
+		Body:       ioutil.NopCloser(bytes.NewReader(entry.Body)),
+		Header:     header,
+		Request:    req,
+	}
+}
+
+// roundTripperFunc adapts an httpClient to an http.RoundTripper so it can
+// back a stdlib *http.Client, which go-github requires.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// getTags lists every tag of p's repo via go-github, which handles
+// pagination and typed responses for us (and, through client's transport,
+// conditional GETs and rate limiting), filtered by p.TagFilter.
+func getTags(client httpClient, p Project) []string {
+	gh := github.NewClient(&http.Client{Transport: roundTripperFunc(client)})
+	ctx := context.Background()
+
+	result := []string{}
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		tags, resp, err := gh.Repositories.ListTags(ctx, p.owner(), p.repoName(), opts)
+		if err != nil {
+			fmt.Println(err.Error())
+			return result
+		}
+		for _, t := range tags {
+			if p.matchesTag(t.GetName()) {
+				result = append(result, t.GetName())
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return result
+}
+
+func readSumFileLines(client httpClient, p Project, version string) []string {
+	lines := []string{}
+	url := fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s", p.Repo, version, p.GoSumPath)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		fmt.Println(err.Error())
+		return lines
+	}
+	resp, err := client(req)
+	if err != nil {
+		fmt.Println(err.Error())
+		return lines
+	}
+	lines = parseSumFile(resp.Body)
+	resp.Body.Close()
+	return lines
+}
@@ -6,20 +6,29 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
-	"log"
 	"net/http"
-	"strings"
-	"time"
+	"os"
 
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
-	versions, _ := getVersionDeps()
+	if err := newRootCmd().Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// serve runs the validator as a Gin HTTP server on :8080, backing both the
+// finder/validator web pages and the JSON API.
+func serve() {
+	allVersions, _ := getVersionDeps()
+	versions := allVersions[defaultProjectName]
 
 	e := gin.Default()
 
-	// bind website
+	// bind website - the finder/validator pages only ever show the
+	// default project (krakend-ce); there's no per-project UI yet.
 	{
 		e.LoadHTMLFiles("finder.html", "validator.html")
 
@@ -50,19 +59,61 @@ func main() {
 		e.GET("/versions/:version", func(c *gin.Context) {
 			version := c.Param("version")
 			if v, ok := versions[version]; ok {
-				c.HTML(http.StatusOK, "finder.html", VersionResponse{Name: version, Version: v})
+				c.HTML(http.StatusOK, "finder.html", buildVersionResponse(versions, version, v, c))
 				return
 			}
 			c.AbortWithStatus(404)
 		})
 
 		e.GET("/", func(c *gin.Context) {
-			c.HTML(http.StatusOK, "finder.html", VersionResponse{Name: "v1.3.0", Version: versions["v1.3.0"]})
+			c.HTML(http.StatusOK, "finder.html", buildVersionResponse(versions, "v1.3.0", versions["v1.3.0"], c))
 		})
 	}
 
 	// bind api
 	{
+		e.GET("/api/projects/:project/versions/:version", func(c *gin.Context) {
+			project := c.Param("project")
+			version := c.Param("version")
+			if v, ok := allVersions[project][version]; ok {
+				c.JSON(200, v)
+				return
+			}
+			c.AbortWithStatus(404)
+		})
+
+		e.GET("/api/projects/:project/versions", func(c *gin.Context) {
+			project := c.Param("project")
+			v, ok := allVersions[project]
+			if !ok {
+				c.AbortWithStatus(404)
+				return
+			}
+			c.JSON(200, v)
+		})
+
+		e.POST("/api/projects/:project/validate/:version/:go_version", func(c *gin.Context) {
+			project := c.Param("project")
+			version := c.Param("version")
+			goVersion := c.Param("go_version")
+			a, ok := allVersions[project][version]
+			if !ok {
+				c.AbortWithStatus(404)
+				return
+			}
+			diffs := checkLines(a, goVersion, parseSumFile(c.Request.Body))
+			if len(diffs) == 0 {
+				c.JSON(200, []string{})
+				return
+			}
+
+			c.JSON(400, diffs)
+		})
+
+		e.POST("/api/projects/:project/remediate/:version/:go_version", remediateHandler(allVersions))
+
+		// /api/versions/... is kept as an alias to the default
+		// krakend-ce project for backwards compatibility.
 		e.GET("/api/versions/:version", func(c *gin.Context) {
 			version := c.Param("version")
 			if v, ok := versions[version]; ok {
@@ -91,105 +142,132 @@ func main() {
 			}
 
 			c.JSON(400, diffs)
-
 		})
+
+		e.POST("/api/remediate/:krakend_version/:go_version", remediateHandler(map[string]map[string]Version{defaultProjectName: versions}))
 	}
 	e.Run(":8080")
 }
 
-func checkLines(version Version, goVersion string, lines []string) []Diff {
-	deps := map[string]string{}
-	for _, dep := range lines {
-		parts := strings.Split(dep, " ")
-		if len(parts) < 2 {
-			log.Println("dep ignored:", dep)
-			continue
+// remediateHandler builds the POST .../remediate/:version/:go_version
+// handler. It's shared between the per-project route and the
+// backwards-compatible default-project alias.
+func remediateHandler(allVersions map[string]map[string]Version) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		project := c.Param("project")
+		if project == "" {
+			project = defaultProjectName
+		}
+		version := c.Param("version")
+		if version == "" {
+			version = c.Param("krakend_version")
 		}
-		cleanedVersion := cleanVersion(parts[1])
+		goVersion := c.Param("go_version")
 
-		if deps[parts[0]] >= cleanedVersion {
-			continue
+		a, ok := allVersions[project][version]
+		if !ok {
+			c.AbortWithStatus(404)
+			return
+		}
+
+		sumFile, err := c.FormFile("go.sum")
+		if err != nil {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+		sum, err := sumFile.Open()
+		if err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		defer sum.Close()
+
+		diffs := checkLines(a, goVersion, parseSumFile(sum))
+
+		var modSrc []byte
+		if modFile, err := c.FormFile("go.mod"); err == nil {
+			mod, err := modFile.Open()
+			if err != nil {
+				c.AbortWithError(http.StatusInternalServerError, err)
+				return
+			}
+			defer mod.Close()
+			if modSrc, err = ioutil.ReadAll(mod); err != nil {
+				c.AbortWithError(http.StatusInternalServerError, err)
+				return
+			}
 		}
-		deps[parts[0]] = cleanedVersion
+
+		patch, err := Remediate(diffs, "go.mod", modSrc)
+		if err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+
+		c.Data(http.StatusOK, "text/x-diff", patch)
 	}
+}
+
+func checkLines(version Version, goVersion string, lines []string) []Diff {
 	b := Version{
 		Go:   goVersion,
-		Deps: deps,
+		Deps: parseDeps(lines),
 	}
 
 	return checkVersion(version, b)
 }
 
-func getVersionDeps() (map[string]Version, error) {
+// getVersionDeps returns the known dependency sets for every tracked
+// project, keyed first by Project.Name and then by tag.
+func getVersionDeps() (map[string]map[string]Version, error) {
 	b, err := ioutil.ReadFile("versions.json")
 	if err != nil {
-		return getUpdatedVersionsDeps(), nil
-	}
-	versions := map[string]Version{}
-	if err := json.Unmarshal(b, &versions); err != nil {
-		return getUpdatedVersionsDeps(), nil
+		return getUpdatedVersionsDeps()
 	}
-	return versions, nil
-}
 
-func getUpdatedVersionsDeps() map[string]Version {
-	client := newHTTPClient()
+	versions := map[string]map[string]Version{}
+	if err := json.Unmarshal(b, &versions); err == nil {
+		return versions, nil
+	}
 
-	versions := map[string]Version{}
-	for _, v := range getTags(client) {
-		fmt.Println("checking version:", v)
-		versions[v] = getUpdatedVersionDeps(client, v)
+	// Deployments from before projects.yaml wrote versions.json as a flat
+	// tag -> Version map. Migrate it under the default project instead of
+	// silently refetching everything from GitHub on every start.
+	legacy := map[string]Version{}
+	if err := json.Unmarshal(b, &legacy); err == nil {
+		return map[string]map[string]Version{defaultProjectName: legacy}, nil
 	}
 
-	return versions
+	return getUpdatedVersionsDeps()
 }
 
-func getUpdatedVersionDeps(c httpClient, v string) Version {
-	deps := map[string]string{}
-	for _, dep := range readSumFileLines(c, v) {
-		parts := strings.Split(dep, " ")
-		cleanedVersion := cleanVersion(parts[1])
-
-		if deps[parts[0]] >= cleanedVersion {
-			continue
-		}
-		deps[parts[0]] = cleanedVersion
-	}
-	return Version{
-		Go:   "",
-		Deps: deps,
+func getUpdatedVersionsDeps() (map[string]map[string]Version, error) {
+	projects, err := loadProjects()
+	if err != nil {
+		return nil, err
 	}
-}
 
-type httpClient func(*http.Request) (*http.Response, error)
+	client := newHTTPClient()
 
-func newHTTPClient() httpClient {
-	timer := time.NewTicker(3 * time.Minute)
-	type response struct {
-		resp *http.Response
-		err  error
-	}
-	type request struct {
-		req *http.Request
-		out chan response
+	all := map[string]map[string]Version{}
+	for _, p := range projects {
+		versions := map[string]Version{}
+		for _, v := range getTags(client, p) {
+			fmt.Println("checking version:", p.Name, v)
+			versions[v] = getUpdatedVersionDeps(client, p, v)
+		}
+		all[p.Name] = versions
 	}
 
-	in := make(chan request)
-
-	go func() {
-		for {
-			<-timer.C
-			r := <-in
-			resp, err := http.DefaultClient.Do(r.req)
-			r.out <- response{resp, err}
-		}
-	}()
+	return all, nil
+}
 
-	return func(req *http.Request) (*http.Response, error) {
-		out := make(chan response)
-		in <- request{req: req, out: out}
-		r := <-out
-		return r.resp, r.err
+func getUpdatedVersionDeps(c httpClient, p Project, v string) Version {
+	deps := parseDeps(readSumFileLines(c, p, v))
+	verifyDepsAgainstSumDB(c, p.Name, v, deps)
+	return Version{
+		Go:   "",
+		Deps: deps,
 	}
 }
 
@@ -200,42 +278,6 @@ func cleanVersion(v string) string {
 	return v
 }
 
-func getTags(client httpClient) []string {
-	req, _ := http.NewRequest("GET", "https://api.github.com/repos/devopsfaith/krakend-ce/tags", nil)
-	req.Header.Add("user-agent", userAgent)
-	resp, err := client(req)
-	if err != nil {
-		fmt.Println(err.Error())
-		return []string{}
-	}
-
-	tags := Tags{}
-	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
-		fmt.Println(err.Error())
-		return []string{}
-	}
-
-	defer resp.Body.Close()
-	result := make([]string, len(tags))
-
-	for i, t := range tags {
-		result[i] = t.Name
-	}
-
-	return result
-}
-
-type Tags []struct {
-	Name       string `json:"name"`
-	ZipballURL string `json:"zipball_url"`
-	TarballURL string `json:"tarball_url"`
-	Commit     struct {
-		Sha string `json:"sha"`
-		URL string `json:"url"`
-	} `json:"commit"`
-	NodeID string `json:"node_id"`
-}
-
 type ValidateResponse struct {
 	GoVersion      string
 	KrakendVersion string
@@ -245,23 +287,71 @@ type ValidateResponse struct {
 type VersionResponse struct {
 	Name    string
 	Version Version
+
+	// Majors holds the latest release of every major version line, for
+	// the "switch major" links.
+	Majors VersionList
+
+	// CompareFrom/CompareTo/CompareDiff are populated from the ?from=&to=
+	// query parameters to render a migration diff between two versions.
+	CompareFrom string
+	CompareTo   string
+	CompareDiff []Diff
+}
+
+// buildVersionResponse assembles the data the finder page needs: the
+// requested version itself, the latest release of every major line, and -
+// when the request carries ?from=&to= query parameters for two known
+// versions - a dependency diff between them.
+func buildVersionResponse(versions map[string]Version, name string, v Version, c *gin.Context) VersionResponse {
+	resp := VersionResponse{
+		Name:    name,
+		Version: v,
+		Majors:  newVersionList(versions).LatestPerMajor(),
+	}
+
+	from, to := c.Query("from"), c.Query("to")
+	a, aok := versions[from]
+	b, bok := versions[to]
+	if aok && bok {
+		resp.CompareFrom = from
+		resp.CompareTo = to
+		resp.CompareDiff = compareDeps(a, b)
+	}
+
+	return resp
 }
 
 type Version struct {
 	Go   string
-	Deps map[string]string
+	Deps map[string]DepVersion
 }
 
+// DiffKind distinguishes a module pinned to the wrong version from one
+// pinned to the right version but built from a go.sum hash that doesn't
+// match.
+type DiffKind string
+
+const (
+	DiffKindVersion DiffKind = "version_mismatch"
+	DiffKindHash    DiffKind = "hash_mismatch"
+	DiffKindAdded   DiffKind = "added"
+	DiffKindRemoved DiffKind = "removed"
+)
+
 type Diff struct {
-	Name     string
-	Expected string
-	Have     string
+	Name        string
+	Expected    string
+	Have        string
+	IsDowngrade bool
+	Severity    Severity
+	Kind        DiffKind
 }
 
 func checkVersion(a, b Version) []Diff {
 	diffs := []Diff{}
 	if a.Go != b.Go {
-		diffs = append(diffs, Diff{Name: "go", Expected: a.Go, Have: b.Go})
+		diffs = append(diffs, Diff{Name: "go", Expected: a.Go, Have: b.Go, Kind: DiffKindVersion})
 	}
 
 	for k, expect := range a.Deps {
@@ -269,27 +359,52 @@ func checkVersion(a, b Version) []Diff {
 		if !ok {
 			continue
 		}
-		if have != expect {
-			diffs = append(diffs, Diff{Name: k, Expected: expect, Have: have})
+
+		if have.Version != expect.Version {
+			diffs = append(diffs, Diff{
+				Name:        k,
+				Expected:    expect.Version,
+				Have:        have.Version,
+				IsDowngrade: compareVersions(have.Version, expect.Version) < 0,
+				Severity:    severity(expect.Version, have.Version),
+				Kind:        DiffKindVersion,
+			})
+			continue
+		}
+
+		if expect.ZipHash != "" && have.ZipHash != "" && expect.ZipHash != have.ZipHash {
+			diffs = append(diffs, Diff{Name: k, Expected: expect.ZipHash, Have: have.ZipHash, Kind: DiffKindHash})
+		}
+		if expect.ModHash != "" && have.ModHash != "" && expect.ModHash != have.ModHash {
+			diffs = append(diffs, Diff{Name: k, Expected: expect.ModHash, Have: have.ModHash, Kind: DiffKindHash})
 		}
 	}
 
 	return diffs
 }
 
-func readSumFileLines(client httpClient, version string) []string {
-	lines := []string{}
-	url := fmt.Sprintf("https://raw.githubusercontent.com/devopsfaith/krakend-ce/%s/go.sum", version)
-	req, nil := http.NewRequest("GET", url, nil)
-	req.Header.Add("user-agent", userAgent)
-	resp, err := client(req)
-	if err != nil {
-		fmt.Println(err.Error())
-		return lines
+// compareDeps diffs two Versions over the union of their dependencies, for
+// the finder's migration compare view. checkVersion only walks a.Deps and
+// skips anything missing from b, which is the right call when b is a
+// plugin's go.sum being checked against a target release: a plugin simply
+// not depending on something the target does isn't a problem. Here both a
+// and b are full releases of the same project, so a module present in one
+// and not the other is real churn worth surfacing, not noise.
+func compareDeps(a, b Version) []Diff {
+	diffs := checkVersion(a, b)
+
+	for k, expect := range a.Deps {
+		if _, ok := b.Deps[k]; !ok {
+			diffs = append(diffs, Diff{Name: k, Expected: expect.Version, Kind: DiffKindRemoved})
+		}
 	}
-	lines = parseSumFile(resp.Body)
-	resp.Body.Close()
-	return lines
+	for k, have := range b.Deps {
+		if _, ok := a.Deps[k]; !ok {
+			diffs = append(diffs, Diff{Name: k, Have: have.Version, Kind: DiffKindAdded})
+		}
+	}
+
+	return diffs
 }
 
 func parseSumFile(r io.Reader) []string {
@@ -303,7 +418,3 @@ func parseSumFile(r io.Reader) []string {
 	}
 	return lines
 }
-
-const (
-	userAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_14_4) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/84.0.4147.125 Safari/537.36"
-)
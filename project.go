@@ -0,0 +1,89 @@
+package main
+
+import (
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Project describes an upstream repository plugin authors can validate
+// their dependencies against: KrakenD CE itself, an enterprise build, a
+// fork, or an entirely different framework built on the same plugin
+// interfaces.
+type Project struct {
+	Name      string `yaml:"name"`
+	Repo      string `yaml:"repo"`       // GitHub "owner/name"
+	TagFilter string `yaml:"tag_filter"` // optional regexp tags must match
+	GoSumPath string `yaml:"go_sum_path"`
+}
+
+// defaultProjectName is kept tracking krakend-ce so the pre-existing
+// /api/versions/... routes keep working as an alias to it.
+const defaultProjectName = "krakend-ce"
+
+func defaultProject() Project {
+	return Project{
+		Name:      defaultProjectName,
+		Repo:      "devopsfaith/krakend-ce",
+		GoSumPath: "go.sum",
+	}
+}
+
+// loadProjects reads the set of upstream projects to track from
+// projects.yaml. When the file doesn't exist, the tool falls back to
+// tracking krakend-ce alone so existing deployments keep working unchanged.
+func loadProjects() ([]Project, error) {
+	b, err := ioutil.ReadFile("projects.yaml")
+	if err != nil {
+		return []Project{defaultProject()}, nil
+	}
+
+	projects := []Project{}
+	if err := yaml.Unmarshal(b, &projects); err != nil {
+		return nil, err
+	}
+	if len(projects) == 0 {
+		return []Project{defaultProject()}, nil
+	}
+
+	for i := range projects {
+		if projects[i].GoSumPath == "" {
+			projects[i].GoSumPath = "go.sum"
+		}
+	}
+
+	return projects, nil
+}
+
+func (p Project) owner() string {
+	owner, _ := p.ownerAndName()
+	return owner
+}
+
+func (p Project) repoName() string {
+	_, name := p.ownerAndName()
+	return name
+}
+
+func (p Project) ownerAndName() (string, string) {
+	if i := strings.IndexByte(p.Repo, '/'); i >= 0 {
+		return p.Repo[:i], p.Repo[i+1:]
+	}
+	return "", p.Repo
+}
+
+// matchesTag reports whether a tag should be tracked for this project. A
+// project with no TagFilter tracks every tag, matching the original,
+// unfiltered krakend-ce behaviour.
+func (p Project) matchesTag(tag string) bool {
+	if p.TagFilter == "" {
+		return true
+	}
+	re, err := regexp.Compile(p.TagFilter)
+	if err != nil {
+		return true
+	}
+	return re.MatchString(tag)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"golang.org/x/mod/modfile"
+)
+
+// Remediate produces a patch that brings a plugin's go.mod in line with
+// the target KrakenD version, given the diffs checkVersion already found.
+//
+// When modSrc is nil there's nothing to diff against, so it emits a
+// standalone `replace (...)` block the author can paste into their
+// go.mod by hand. When modSrc holds the plugin's current go.mod, it's
+// parsed with modfile, the same replace directives (plus an updated `go`
+// line, when that's what diverged) are added to the AST, and a unified
+// diff against the original file is returned instead.
+func Remediate(diffs []Diff, modPath string, modSrc []byte) ([]byte, error) {
+	if len(diffs) == 0 {
+		return nil, nil
+	}
+
+	if modSrc == nil {
+		return replaceBlock(diffs), nil
+	}
+
+	return remediateModFile(diffs, modPath, modSrc)
+}
+
+func replaceBlock(diffs []Diff) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintln(&buf, "replace (")
+	for _, d := range diffs {
+		if d.Name == "go" || d.Kind == DiffKindHash {
+			continue
+		}
+		fmt.Fprintf(&buf, "\t%s => %s %s\n", d.Name, d.Name, d.Expected)
+	}
+	fmt.Fprintln(&buf, ")")
+	return buf.Bytes()
+}
+
+func remediateModFile(diffs []Diff, modPath string, modSrc []byte) ([]byte, error) {
+	f, err := modfile.Parse(modPath, modSrc, nil)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", modPath, err)
+	}
+
+	for _, d := range diffs {
+		if d.Kind == DiffKindHash {
+			// A hash mismatch with a matching version can't be fixed by
+			// editing go.mod - it needs a clean re-download of the module.
+			continue
+		}
+		if d.Name == "go" {
+			if err := f.AddGoStmt(d.Expected); err != nil {
+				return nil, fmt.Errorf("setting go directive: %w", err)
+			}
+			continue
+		}
+		if err := f.AddReplace(d.Name, "", d.Name, d.Expected); err != nil {
+			return nil, fmt.Errorf("adding replace for %s: %w", d.Name, err)
+		}
+	}
+	f.Cleanup()
+
+	after, err := f.Format()
+	if err != nil {
+		return nil, fmt.Errorf("formatting remediated go.mod: %w", err)
+	}
+
+	return unifiedDiff(modPath, modSrc, after), nil
+}
+
+func unifiedDiff(path string, before, after []byte) []byte {
+	diff := difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(before)),
+		B:        difflib.SplitLines(string(after)),
+		FromFile: path,
+		ToFile:   path,
+		Context:  3,
+	}
+	text, _ := difflib.GetUnifiedDiffString(diff)
+	return []byte(text)
+}
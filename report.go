@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// writeReport renders diffs in the requested format. It mirrors the shape
+// of the POST /api/versions/... response for "json" so the same payload
+// can be consumed by either the HTTP API or the CLI.
+func writeReport(w io.Writer, format, sumPath string, diffs []Diff) error {
+	switch format {
+	case "", "text":
+		return writeTextReport(w, diffs)
+	case "json":
+		return json.NewEncoder(w).Encode(diffs)
+	case "github":
+		return writeGithubReport(w, sumPath, diffs)
+	case "sarif":
+		return writeSarifReport(w, sumPath, diffs)
+	default:
+		return fmt.Errorf("unknown format %q: must be one of text, json, sarif, github", format)
+	}
+}
+
+func writeTextReport(w io.Writer, diffs []Diff) error {
+	if len(diffs) == 0 {
+		fmt.Fprintln(w, "OK: go.sum matches the target KrakenD version")
+		return nil
+	}
+	for _, d := range diffs {
+		fmt.Fprintf(w, "%s: expected %s, have %s (%s)\n", d.Name, d.Expected, d.Have, diffSummary(d))
+	}
+	return nil
+}
+
+func writeGithubReport(w io.Writer, sumPath string, diffs []Diff) error {
+	for _, d := range diffs {
+		fmt.Fprintf(w, "::error file=%s::%s: expected %s, have %s (%s)\n", sumPath, d.Name, d.Expected, d.Have, diffSummary(d))
+	}
+	return nil
+}
+
+func diffSummary(d Diff) string {
+	if d.Kind == DiffKindHash {
+		return "go.sum hash mismatch"
+	}
+	if d.Name == "go" {
+		return "go directive mismatch"
+	}
+	if d.Severity == "" {
+		return "mismatch"
+	}
+	if d.IsDowngrade {
+		return string(d.Severity) + " downgrade"
+	}
+	return string(d.Severity) + " upgrade"
+}
+
+// SARIF 2.1.0 (https://docs.oasis-open.org/sarif/sarif/v2.1.0/) subset
+// needed to surface diffs as GitHub code-scanning annotations.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+func writeSarifReport(w io.Writer, sumPath string, diffs []Diff) error {
+	results := make([]sarifResult, 0, len(diffs))
+	for _, d := range diffs {
+		results = append(results, sarifResult{
+			RuleID: "dependency-mismatch",
+			Level:  sarifLevel(d),
+			Message: sarifMessage{
+				Text: fmt.Sprintf("%s: expected %s, have %s (%s)", d.Name, d.Expected, d.Have, diffSummary(d)),
+			},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: sumPath},
+				},
+			}},
+		})
+	}
+
+	report := sarifLog{
+		Schema:  "https://raw.githubusercontent.com/oasis-tcs/sarif-spec/master/Schemata/sarif-schema-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool:    sarifTool{Driver: sarifDriver{Name: "plugin-tools", Version: toolVersion}},
+			Results: results,
+		}},
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+func sarifLevel(d Diff) string {
+	if d.Kind == DiffKindHash {
+		return "error"
+	}
+	switch d.Severity {
+	case SeverityMajor:
+		return "error"
+	case SeverityMinor, SeverityPseudo:
+		return "warning"
+	default:
+		return "note"
+	}
+}
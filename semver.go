@@ -0,0 +1,76 @@
+package main
+
+import (
+	"regexp"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// Severity classifies how far apart two module versions are, so callers can
+// decide whether a diff is worth failing a build over.
+type Severity string
+
+const (
+	SeverityNone   Severity = ""
+	SeverityMajor  Severity = "major"
+	SeverityMinor  Severity = "minor"
+	SeverityPatch  Severity = "patch"
+	SeverityPseudo Severity = "pseudo"
+)
+
+// pseudoVersionRE matches the "vX.Y.Z-yyyymmddhhmmss-abcdefabcdef" shape
+// described in https://go.dev/ref/mod#pseudo-versions.
+var pseudoVersionRE = regexp.MustCompile(`^v\d+\.\d+\.\d+-\d{14}-[0-9a-f]{12}(\+incompatible)?$`)
+
+// isPseudoVersion reports whether v looks like a Go module pseudo-version
+// rather than a tagged release.
+func isPseudoVersion(v string) bool {
+	return pseudoVersionRE.MatchString(v)
+}
+
+// canonicalVersion cleans up a module@version pair so that e.g. a missing
+// "v" prefix doesn't break comparison. module.Check only validates that
+// mod@v is a well-formed module path and version (it returns no canonical
+// form); the actual canonicalization is semver.Canonical. That strips
+// build metadata, including the "+incompatible" suffix Go appends to
+// pre-module major versions, so it's re-appended afterwards - dropping it
+// would store a version go itself rejects (v2.0.0 without +incompatible
+// isn't a valid module version for a v2+ module without a go.mod). It
+// falls back to the raw string if the version can't be canonicalized
+// (e.g. it isn't valid semver at all).
+func canonicalVersion(mod, v string) string {
+	if err := module.Check(mod, v); err != nil {
+		return v
+	}
+	if !semver.IsValid(v) {
+		return v
+	}
+	return semver.Canonical(v) + semver.Build(v)
+}
+
+// compareVersions orders two module versions using semver.Compare, with
+// pseudo-versions ordered by their embedded timestamp per the Go modules
+// spec: a pseudo-version is only ever "newer" than the tagged version it
+// was generated from when its timestamp is later, so comparing the full
+// strings lexicographically (as semver.Compare already does for the
+// pseudo-version's trailing "-timestamp-commit" suffix) gives the right
+// answer once both sides are canonicalized.
+func compareVersions(a, b string) int {
+	return semver.Compare(a, b)
+}
+
+// severity classifies the difference between expected and have, assuming
+// have != expected.
+func severity(expected, have string) Severity {
+	if isPseudoVersion(expected) || isPseudoVersion(have) {
+		return SeverityPseudo
+	}
+	if semver.Major(expected) != semver.Major(have) {
+		return SeverityMajor
+	}
+	if semver.MajorMinor(expected) != semver.MajorMinor(have) {
+		return SeverityMinor
+	}
+	return SeverityPatch
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"log"
+	"strings"
+)
+
+// DepVersion records what a go.sum file said about a dependency: the
+// version pinned in go.mod, and the Go checksum database hashes for its
+// module zip and go.mod file. Keeping both hashes lets checkVersion catch
+// a plugin that pins the "right" version of a module built from a
+// tampered proxy, which a version-only comparison would miss entirely.
+type DepVersion struct {
+	Version string
+	ZipHash string
+	ModHash string
+}
+
+// parseDeps folds a go.sum file's lines into one DepVersion per module.
+// Each module appears as two lines - "module version h1:hash=" for the
+// zip and "module version/go.mod h1:hash=" for the go.mod file - so this
+// keys on the version and fills in whichever hash the line carries. When
+// a go.sum lists more than one version of a module (normal during MVS),
+// only the highest version's hashes are kept, matching the version Go's
+// module graph actually resolves to.
+func parseDeps(lines []string) map[string]DepVersion {
+	deps := map[string]DepVersion{}
+	for _, line := range lines {
+		parts := strings.Fields(line)
+		if len(parts) < 3 {
+			log.Println("dep ignored:", line)
+			continue
+		}
+
+		mod := parts[0]
+		isGoModHash := strings.HasSuffix(parts[1], "/go.mod")
+		version := canonicalVersion(mod, cleanVersion(parts[1]))
+
+		dep, ok := deps[mod]
+		if !ok || compareVersions(dep.Version, version) < 0 {
+			dep = DepVersion{Version: version}
+		}
+		if dep.Version != version {
+			continue
+		}
+		if isGoModHash {
+			dep.ModHash = parts[2]
+		} else {
+			dep.ZipHash = parts[2]
+		}
+		deps[mod] = dep
+	}
+	return deps
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"sort"
+
+	"golang.org/x/mod/semver"
+)
+
+// TaggedVersion pairs a tag (e.g. "v1.3.0") with the dependency set it
+// resolved to.
+type TaggedVersion struct {
+	Tag     string
+	Version Version
+}
+
+// VersionList sorts TaggedVersions newest-first by semver, so the finder
+// page can group them by major line without re-deriving ordering from raw
+// tag strings every time it renders.
+type VersionList []TaggedVersion
+
+func (l VersionList) Len() int      { return len(l) }
+func (l VersionList) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
+func (l VersionList) Less(i, j int) bool {
+	return compareVersions(l[i].Tag, l[j].Tag) > 0
+}
+
+// newVersionList builds a sorted VersionList out of a project's tag ->
+// Version map.
+func newVersionList(versions map[string]Version) VersionList {
+	list := make(VersionList, 0, len(versions))
+	for tag, v := range versions {
+		list = append(list, TaggedVersion{Tag: tag, Version: v})
+	}
+	sort.Sort(list)
+	return list
+}
+
+// LatestPerMajor returns the newest tag of every major version line
+// present in l, in the same newest-first order, e.g. {v2.3.1, v1.9.0} out
+// of {v1.8.0, v1.9.0, v2.3.0, v2.3.1}. This both picks what to show by
+// default for each major line and doubles as the set of "switch major"
+// links, since it's already exactly one entry per line.
+func (l VersionList) LatestPerMajor() VersionList {
+	seen := map[string]bool{}
+	result := make(VersionList, 0)
+	for _, tv := range l {
+		major := semver.Major(tv.Tag)
+		if seen[major] {
+			continue
+		}
+		seen[major] = true
+		result = append(result, tv)
+	}
+	return result
+}